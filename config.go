@@ -0,0 +1,296 @@
+package flags
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFormat selects the on-disk encoding used by Parser.LoadConfigFile
+// and Parser.WriteConfigFile.
+type ConfigFormat int
+
+const (
+	// ConfigFormatJSON reads/writes plain JSON.
+	ConfigFormatJSON ConfigFormat = iota
+
+	// ConfigFormatYAML reads/writes YAML. Internally it is converted
+	// to/from JSON so a single code path drives both formats.
+	ConfigFormatYAML
+)
+
+// LoadConfigFile reads the structured (JSON or YAML) config file at path
+// and layers it into the parser's precedence chain, between the
+// compiled-in defaults and the environment:
+//
+//	defaults < config file < env < command line
+//
+// Each option is matched against the config document by its `json` tag
+// (falling back to `long` when no `json` tag is present; note this is the
+// reverse of the long-then-json order used for flags and env names). A
+// string value for a slice/map option is split on its EnvDelim, as an env
+// value would be. Nested groups and
+// subcommands are matched by recursing into the document object keyed by
+// the group's name. Requires the ConfigFileFallback option to have an
+// effect during ParseCommandLine.
+func (p *Parser) LoadConfigFile(path string, format ConfigFormat) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("flags: failed to read config file %s: %w", path, err)
+	}
+
+	doc, err := decodeConfig(raw, format)
+	if err != nil {
+		return fmt.Errorf("flags: failed to parse config file %s: %w", path, err)
+	}
+
+	p.config = make(map[string][]string)
+	flattenConfig(p.Group, doc, p.config)
+
+	return nil
+}
+
+// WriteConfigFile serializes the parser's current (effective) option
+// values to w in the given format. This is useful for implementing a
+// `--dump-config` flag that lets operators capture the configuration a
+// run actually used. An option resolved via env-file indirection (see
+// Option.secret) is written as secretPlaceholder instead of its real
+// value when the parser's SecretRedact option is set.
+func (p *Parser) WriteConfigFile(w io.Writer, format ConfigFormat) error {
+	doc := buildConfig(p.Group, p.Options&SecretRedact != 0)
+
+	jsonBytes, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("flags: failed to encode config: %w", err)
+	}
+
+	switch format {
+	case ConfigFormatJSON:
+		_, err = w.Write(jsonBytes)
+	case ConfigFormatYAML:
+		var generic interface{}
+		if err = json.Unmarshal(jsonBytes, &generic); err != nil {
+			return fmt.Errorf("flags: failed to encode config: %w", err)
+		}
+
+		var yamlBytes []byte
+		yamlBytes, err = yaml.Marshal(generic)
+		if err != nil {
+			return fmt.Errorf("flags: failed to encode config: %w", err)
+		}
+
+		_, err = w.Write(yamlBytes)
+	default:
+		return fmt.Errorf("flags: unknown config format %d", format)
+	}
+
+	return err
+}
+
+// decodeConfig normalizes either format down to a generic
+// map[string]interface{} by routing YAML through JSON, so the rest of the
+// config loading code only ever deals with JSON-shaped data.
+func decodeConfig(raw []byte, format ConfigFormat) (map[string]interface{}, error) {
+	var generic interface{}
+
+	switch format {
+	case ConfigFormatJSON:
+		if err := json.Unmarshal(raw, &generic); err != nil {
+			return nil, err
+		}
+	case ConfigFormatYAML:
+		if err := yaml.Unmarshal(raw, &generic); err != nil {
+			return nil, err
+		}
+
+		// yaml.Unmarshal produces map[string]interface{} keys already in
+		// yaml.v3, but round-trip through JSON regardless so nested
+		// map[interface{}]interface{} values (possible with custom
+		// decoders) are normalized the same way a JSON document would be.
+		jsonBytes, err := json.Marshal(generic)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(jsonBytes, &generic); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown config format %d", format)
+	}
+
+	doc, ok := generic.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("config document must be an object at the top level")
+	}
+
+	return doc, nil
+}
+
+// flattenConfig walks doc alongside g's options/groups, writing each
+// matched option's value into out keyed by Option.Path(), ready to be
+// consulted by ParseCommandLine.
+func flattenConfig(g *Group, doc map[string]interface{}, out map[string][]string) {
+	for _, option := range g.options {
+		key := option.Json
+		if key == "" {
+			key = option.longOrJSON()
+		}
+
+		raw, ok := doc[key]
+		if !ok {
+			continue
+		}
+
+		if s, isString := raw.(string); isString && option.EnvDelim != "" &&
+			(option.value.Kind() == reflect.Slice || option.value.Kind() == reflect.Map) {
+			out[option.Path()] = strings.Split(s, option.EnvDelim)
+			continue
+		}
+
+		out[option.Path()] = configParts(raw)
+	}
+
+	for _, child := range g.groups {
+		nested, ok := doc[child.name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		flattenConfig(child, nested, out)
+	}
+}
+
+// configParts splits a decoded JSON value into the individual elements
+// Option.applyParts expects: one element per array entry, one "key:value"
+// pair per object entry, or a single element for a scalar. Unlike the
+// env/ini path, this never round-trips a multi-element array/object
+// through a delimited string, so a config file's own JSON/YAML structure
+// is what determines each element's boundary, not EnvDelim.
+func configParts(v interface{}) []string {
+	switch val := v.(type) {
+	case []interface{}:
+		parts := make([]string, len(val))
+		for i, elem := range val {
+			parts[i] = configScalar(elem, "")
+		}
+		return parts
+	case map[string]interface{}:
+		parts := make([]string, 0, len(val))
+		for k, elem := range val {
+			parts = append(parts, fmt.Sprintf("%s:%s", k, configScalar(elem, "")))
+		}
+		return parts
+	default:
+		return []string{configScalar(val, "")}
+	}
+}
+
+// configScalar renders a single decoded JSON value into the string form
+// convertScalar/buildValue expect. It only recurses into a delimited
+// string for a slice/map nested *inside* another slice/map's element
+// (e.g. a map value that is itself a slice), a shape configParts itself
+// does not unpack further.
+func configScalar(v interface{}, delim string) string {
+	switch val := v.(type) {
+	case []interface{}:
+		parts := make([]string, len(val))
+		for i, elem := range val {
+			parts[i] = configScalar(elem, delim)
+		}
+		return joinWithDelim(parts, delim)
+	case map[string]interface{}:
+		parts := make([]string, 0, len(val))
+		for k, elem := range val {
+			parts = append(parts, fmt.Sprintf("%s:%s", k, configScalar(elem, delim)))
+		}
+		return joinWithDelim(parts, delim)
+	case string:
+		return val
+	case float64:
+		// JSON numbers decode as float64; plain decimal form keeps large
+		// integers like 1000000 parseable by strconv.ParseInt.
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+func joinWithDelim(parts []string, delim string) string {
+	if delim == "" {
+		delim = ","
+	}
+
+	out := ""
+	for i, p := range parts {
+		if i > 0 {
+			out += delim
+		}
+		out += p
+	}
+	return out
+}
+
+// buildConfig walks g's options/groups, producing a nested
+// map[string]interface{} of effective values suitable for
+// json.Marshal/yaml.Marshal, mirroring the shape LoadConfigFile expects
+// to read back. redact, when true, replaces a secret-sourced option's
+// value with secretPlaceholder instead of its real value.
+func buildConfig(g *Group, redact bool) map[string]interface{} {
+	doc := make(map[string]interface{})
+
+	for _, option := range g.options {
+		key := option.Json
+		if key == "" {
+			key = option.longOrJSON()
+		}
+
+		if redact && option.secret {
+			doc[key] = secretPlaceholder
+			continue
+		}
+
+		doc[key] = configValue(option.value)
+	}
+
+	for _, child := range g.groups {
+		doc[child.name] = buildConfig(child, redact)
+	}
+
+	return doc
+}
+
+// configValue converts an option's reflect.Value into a plain Go value
+// (as opposed to the delimited-string form used internally) so it
+// serializes as a native JSON/YAML array or object rather than a string.
+// time.Duration is special-cased to its String() form (e.g. "10s")
+// rather than its raw int64 nanosecond count: read back, a bare
+// nanosecond count has no unit, which time.ParseDuration rejects.
+func configValue(value reflect.Value) interface{} {
+	if value.Type() == reflect.TypeOf(time.Duration(0)) {
+		return time.Duration(value.Int()).String()
+	}
+
+	switch value.Kind() {
+	case reflect.Slice:
+		out := make([]interface{}, value.Len())
+		for i := 0; i < value.Len(); i++ {
+			out[i] = configValue(value.Index(i))
+		}
+		return out
+	case reflect.Map:
+		out := make(map[string]interface{}, value.Len())
+		for _, key := range value.MapKeys() {
+			out[stringifyScalar(key)] = configValue(value.MapIndex(key))
+		}
+		return out
+	default:
+		return value.Interface()
+	}
+}
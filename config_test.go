@@ -0,0 +1,178 @@
+package flags
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestConfigFilePrecedence checks that a loaded config file sits between
+// the compiled-in defaults and the environment, for both JSON and YAML
+// documents, matching the source/priority ordering exercised for
+// env/CLI in TestFallback.
+func TestConfigFilePrecedence(t *testing.T) {
+	type Options struct {
+		Int   int            `long:"int" json:"json-int" default:"1"`
+		Time  time.Duration  `json:"time" default:"1m"`
+		Map   map[string]int `json:"map,omitempty" default:"a:1" env-delim:";"`
+		Slice []int          `long:"slice" default:"1" default:"2" env:"OVERRIDE_SLICE" env-delim:","`
+	}
+
+	var tests = []struct {
+		msg      string
+		format   ConfigFormat
+		contents string
+		args     []string
+		env      map[string]string
+		expected Options
+	}{
+		{
+			msg:    "JSON config fills in over defaults",
+			format: ConfigFormatJSON,
+			contents: `{
+				"json-int": 5,
+				"time": "4m",
+				"map": {"a": 9}
+			}`,
+			expected: Options{
+				Int:   5,
+				Time:  4 * time.Minute,
+				Map:   map[string]int{"a": 9},
+				Slice: []int{1, 2},
+			},
+		},
+		{
+			msg:    "JSON config with a multi-element slice and multi-key map",
+			format: ConfigFormatJSON,
+			contents: `{
+				"json-int": 5,
+				"map": {"a": 9, "b": 2},
+				"slice": [3, 4, 5]
+			}`,
+			expected: Options{
+				Int:   5,
+				Time:  time.Minute,
+				Map:   map[string]int{"a": 9, "b": 2},
+				Slice: []int{3, 4, 5},
+			},
+		},
+		{
+			msg:    "YAML config fills in over defaults",
+			format: ConfigFormatYAML,
+			contents: "json-int: 6\n" +
+				"time: 5m\n" +
+				"map:\n  a: 10\n",
+			expected: Options{
+				Int:   6,
+				Time:  5 * time.Minute,
+				Map:   map[string]int{"a": 10},
+				Slice: []int{1, 2},
+			},
+		},
+		{
+			msg:      "env overrides config file",
+			format:   ConfigFormatJSON,
+			contents: `{"json-int": 5}`,
+			env:      map[string]string{"int": "7"},
+			expected: Options{
+				Int:   7,
+				Time:  time.Minute,
+				Map:   map[string]int{"a": 1},
+				Slice: []int{1, 2},
+			},
+		},
+		{
+			msg:      "CLI overrides config file and env",
+			format:   ConfigFormatJSON,
+			contents: `{"json-int": 5}`,
+			args:     []string{"--int=8"},
+			env:      map[string]string{"int": "7"},
+			expected: Options{
+				Int:   8,
+				Time:  time.Minute,
+				Map:   map[string]int{"a": 1},
+				Slice: []int{1, 2},
+			},
+		},
+	}
+
+	oldEnv := EnvSnapshot()
+	defer oldEnv.Restore()
+
+	for _, test := range tests {
+		oldEnv.Restore()
+		for envKey, envValue := range test.env {
+			os.Setenv(envKey, envValue)
+		}
+
+		dir := t.TempDir()
+		path := filepath.Join(dir, "config")
+		if err := os.WriteFile(path, []byte(test.contents), 0o600); err != nil {
+			t.Fatalf("%s: failed to write config fixture: %v", test.msg, err)
+		}
+
+		var opts Options
+		p := NewParser(&opts, Default|ConfigFileFallback)
+
+		if err := p.LoadConfigFile(path, test.format); err != nil {
+			t.Fatalf("%s: LoadConfigFile: %v", test.msg, err)
+		}
+
+		if _, _, _, err := p.ParseCommandLine(test.args); err != nil {
+			t.Fatalf("%s: ParseCommandLine: %v", test.msg, err)
+		}
+
+		if !reflect.DeepEqual(opts, test.expected) {
+			t.Errorf("%s:\nexpected\n%+v\nbut got\n%+v", test.msg, test.expected, opts)
+		}
+	}
+}
+
+// TestWriteConfigFile checks that WriteConfigFile round-trips through
+// LoadConfigFile for both formats.
+func TestWriteConfigFile(t *testing.T) {
+	type Options struct {
+		Int   int            `long:"int" json:"json-int" default:"1"`
+		Time  time.Duration  `json:"time" default:"1m"`
+		Slice []int          `long:"slice"`
+		Map   map[string]int `long:"map"`
+	}
+
+	for _, format := range []ConfigFormat{ConfigFormatJSON, ConfigFormatYAML} {
+		var opts Options
+		opts.Int = 42
+		opts.Time = 10 * time.Second
+		opts.Slice = []int{3, 4, 5}
+		opts.Map = map[string]int{"a": 9, "b": 2}
+
+		writer := NewParser(&opts, Default)
+
+		var buf bytes.Buffer
+		if err := writer.WriteConfigFile(&buf, format); err != nil {
+			t.Fatalf("WriteConfigFile: %v", err)
+		}
+
+		dir := t.TempDir()
+		path := filepath.Join(dir, "config")
+		if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+			t.Fatalf("failed to write round-trip fixture: %v", err)
+		}
+
+		var readBack Options
+		reader := NewParser(&readBack, Default|ConfigFileFallback)
+		if err := reader.LoadConfigFile(path, format); err != nil {
+			t.Fatalf("LoadConfigFile: %v", err)
+		}
+		if _, _, _, err := reader.ParseCommandLine(nil); err != nil {
+			t.Fatalf("ParseCommandLine: %v", err)
+		}
+
+		if readBack.Int != opts.Int || readBack.Time != opts.Time ||
+			!reflect.DeepEqual(readBack.Slice, opts.Slice) || !reflect.DeepEqual(readBack.Map, opts.Map) {
+			t.Errorf("round-trip mismatch: wrote %+v, read back %+v", opts, readBack)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package flags
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// buildValue parses raw into a freshly allocated value of typ. For
+// scalars, raw is expected to hold a single element; for slices, each
+// element of raw is appended; for maps, each element of raw is a
+// "key:value" pair split on the first colon. The caller decides how the
+// result is combined with any previously accumulated value (see
+// mergeContainers).
+func buildValue(typ reflect.Type, raw []string) (reflect.Value, error) {
+	switch typ.Kind() {
+	case reflect.Slice:
+		return buildSlice(typ, raw)
+	case reflect.Map:
+		return buildMap(typ, raw)
+	default:
+		value := reflect.New(typ).Elem()
+		if len(raw) == 0 {
+			return value, nil
+		}
+		return value, convertScalar(value, raw[len(raw)-1])
+	}
+}
+
+func buildSlice(typ reflect.Type, raw []string) (reflect.Value, error) {
+	slice := reflect.MakeSlice(typ, 0, len(raw))
+
+	for _, elem := range raw {
+		item := reflect.New(typ.Elem()).Elem()
+		if err := convertScalar(item, elem); err != nil {
+			return slice, err
+		}
+		slice = reflect.Append(slice, item)
+	}
+
+	return slice, nil
+}
+
+// buildMap builds a map from "key:value" pairs. When the map's value type
+// is itself a slice or map (e.g. map[string][]int), the value half of the
+// pair recurses through buildValue instead of convertScalar, so the
+// single-occurrence result can still be combined across sources by
+// mergeContainers.
+func buildMap(typ reflect.Type, raw []string) (reflect.Value, error) {
+	m := reflect.MakeMap(typ)
+
+	for _, pair := range raw {
+		kv := strings.SplitN(pair, ":", 2)
+		if len(kv) != 2 {
+			return m, fmt.Errorf("flags: invalid map entry %q, expected key:value", pair)
+		}
+
+		key := reflect.New(typ.Key()).Elem()
+		if err := convertScalar(key, kv[0]); err != nil {
+			return m, err
+		}
+
+		var val reflect.Value
+		if typ.Elem().Kind() == reflect.Slice || typ.Elem().Kind() == reflect.Map {
+			built, err := buildValue(typ.Elem(), []string{kv[1]})
+			if err != nil {
+				return m, err
+			}
+			val = built
+		} else {
+			val = reflect.New(typ.Elem()).Elem()
+			if err := convertScalar(val, kv[1]); err != nil {
+				return m, err
+			}
+		}
+
+		m.SetMapIndex(key, val)
+	}
+
+	return m, nil
+}
+
+// convertScalar converts raw into a single non-slice, non-map value,
+// special-casing time.Duration since it does not fit the normal
+// reflect.Kind switch.
+func convertScalar(value reflect.Value, raw string) error {
+	if value.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(d))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		value.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		value.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		value.SetFloat(f)
+	default:
+		return fmt.Errorf("flags: unsupported option type %s", value.Type())
+	}
+
+	return nil
+}
+
+// stringifyValue renders value back into the string form buildValue
+// would parse, used by WriteIni.
+func stringifyValue(value reflect.Value, delim string) []string {
+	switch value.Kind() {
+	case reflect.Slice:
+		out := make([]string, 0, value.Len())
+		for i := 0; i < value.Len(); i++ {
+			out = append(out, stringifyScalar(value.Index(i)))
+		}
+		return out
+	case reflect.Map:
+		out := make([]string, 0, value.Len())
+		for _, key := range value.MapKeys() {
+			out = append(out, stringifyScalar(key)+":"+stringifyScalar(value.MapIndex(key)))
+		}
+		sort.Strings(out)
+		return out
+	default:
+		return []string{stringifyScalar(value)}
+	}
+}
+
+func stringifyScalar(value reflect.Value) string {
+	if value.Type() == reflect.TypeOf(time.Duration(0)) {
+		return time.Duration(value.Int()).String()
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		return value.String()
+	case reflect.Bool:
+		return strconv.FormatBool(value.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(value.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(value.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(value.Float(), 'g', -1, 64)
+	default:
+		return fmt.Sprintf("%v", value.Interface())
+	}
+}
@@ -0,0 +1,28 @@
+// Package flags provides a reflection based command line and environment
+// variable parser, in the spirit of (and originally forked from)
+// jessevdk/go-flags.
+//
+// Options are described by struct tags on a "group" struct passed to
+// NewParser. The tags understood by the parser are:
+//
+//	long            long flag name, e.g. `long:"port"` for --port
+//	short           short flag name, e.g. `short:"p"` for -p
+//	json            key used when no `long` tag is present, and as the
+//	                key consulted by JSON/YAML config file loading
+//	env             environment variable name; when absent and the
+//	                EnvironmentFallback option is set, it falls back to
+//	                `long`, then `json`, then the struct field name
+//	env-file        environment variable naming a file whose contents are
+//	                the option's real value; when absent but `env` is
+//	                present, the "<env>_FILE" convention is consulted
+//	                automatically
+//	env-delim       delimiter used to split a scalar env/config value into
+//	                a slice or map
+//	default         one or more default values
+//	merge           how to combine a slice/map option's values across
+//	                sources: "replace", "append" or "deep"; defaults to the
+//	                parser's MergeStrategy when absent
+//	description     human readable help text
+//	validate        comma-separated list of "name" or "name=param"
+//	                validators run once every source has been applied
+package flags
@@ -0,0 +1,31 @@
+package flags
+
+import "os"
+
+// Snapshot is a saved copy of the process environment, taken by
+// EnvSnapshot and restored with Restore. Tests use it to run each table
+// case against a clean environment regardless of what earlier cases set.
+type Snapshot struct {
+	vars []string
+}
+
+// EnvSnapshot captures the current process environment so it can later be
+// restored with Snapshot.Restore.
+func EnvSnapshot() Snapshot {
+	return Snapshot{vars: os.Environ()}
+}
+
+// Restore clears the current process environment and replaces it with the
+// one captured by EnvSnapshot.
+func (s Snapshot) Restore() {
+	os.Clearenv()
+
+	for _, kv := range s.vars {
+		for i := 0; i < len(kv); i++ {
+			if kv[i] == '=' {
+				os.Setenv(kv[:i], kv[i+1:])
+				break
+			}
+		}
+	}
+}
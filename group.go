@@ -0,0 +1,352 @@
+package flags
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Group is a set of Options reflected from a struct, optionally nested
+// under a parent group (for `group:"..."` fields) or a subcommand
+// (for `command:"..."` fields). The root group created by NewParser has
+// an empty name.
+type Group struct {
+	name    string
+	options []*Option
+	groups  []*Group
+}
+
+// newGroup reflects over v's fields, turning ordinary fields into Options
+// and nested struct fields into child Groups.
+func newGroup(name string, v reflect.Value) *Group {
+	g := &Group{name: name}
+
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldValue := v.Field(i)
+
+		if !fieldValue.CanSet() {
+			continue
+		}
+
+		if fieldValue.Kind() == reflect.Struct && field.Tag.Get("long") == "" && field.Tag.Get("json") == "" {
+			// A nested struct with no `long`/`json` tag of its own is a
+			// group or subcommand: recurse using its field name (or
+			// `group`/`command` tag) as the namespace.
+			childName := field.Tag.Get("command")
+			if childName == "" {
+				childName = field.Tag.Get("group")
+			}
+			if childName == "" {
+				childName = strings.ToLower(field.Name)
+			}
+
+			g.groups = append(g.groups, newGroup(childName, fieldValue))
+			continue
+		}
+
+		option := &Option{
+			LongName:       field.Tag.Get("long"),
+			ShortName:      field.Tag.Get("short"),
+			Json:           field.Tag.Get("json"),
+			EnvKeyName:     field.Tag.Get("env"),
+			EnvFileKeyName: field.Tag.Get("env-file"),
+			EnvDelim:       field.Tag.Get("env-delim"),
+			Merge:          field.Tag.Get("merge"),
+			Description:    field.Tag.Get("description"),
+			Validate:       field.Tag.Get("validate"),
+			group:          g,
+			field:          field,
+			value:          fieldValue,
+		}
+
+		// `json` may carry `,omitempty` and similar; only the key before
+		// the first comma is used as the name.
+		if idx := strings.Index(option.Json, ","); idx >= 0 {
+			option.Json = option.Json[:idx]
+		}
+
+		if defaults, ok := field.Tag.Lookup("default"); ok {
+			option.Default = splitMultiTag(string(field.Tag), "default")
+			_ = defaults
+		}
+
+		g.options = append(g.options, option)
+	}
+
+	return g
+}
+
+// splitMultiTag collects every occurrence of key in tag, since
+// reflect.StructTag only exposes the last occurrence of a repeated key
+// (e.g. `default:"1" default:"2"` used to build a multi-value default).
+func splitMultiTag(tag, key string) []string {
+	var values []string
+
+	rest := reflect.StructTag(tag)
+	for {
+		raw := string(rest)
+		idx := strings.Index(raw, key+":\"")
+		if idx < 0 {
+			break
+		}
+
+		raw = raw[idx+len(key)+2:]
+		end := strings.Index(raw, "\"")
+		if end < 0 {
+			break
+		}
+
+		values = append(values, raw[:end])
+		rest = reflect.StructTag(raw[end+1:])
+	}
+
+	return values
+}
+
+// walk calls fn for every option in the group and its descendants.
+func (g *Group) walk(fn func(*Option) error) error {
+	for _, option := range g.options {
+		if err := fn(option); err != nil {
+			return err
+		}
+	}
+
+	for _, child := range g.groups {
+		if err := child.walk(fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// findLong returns the option (searching this group and its children)
+// whose `long` tag (falling back to `json`) matches name.
+func (g *Group) findLong(name string) *Option {
+	for _, option := range g.options {
+		if option.longOrJSON() == name {
+			return option
+		}
+	}
+
+	for _, child := range g.groups {
+		if option := child.findLong(name); option != nil {
+			return option
+		}
+	}
+
+	return nil
+}
+
+// findOwnLong returns the option among g's own options (not descendants)
+// whose `long` tag (falling back to `json`) matches name.
+func (g *Group) findOwnLong(name string) *Option {
+	for _, option := range g.options {
+		if option.longOrJSON() == name {
+			return option
+		}
+	}
+
+	return nil
+}
+
+// findShort returns the option (searching this group and its children)
+// whose `short` tag matches name.
+func (g *Group) findShort(name string) *Option {
+	for _, option := range g.options {
+		if option.ShortName == name {
+			return option
+		}
+	}
+
+	for _, child := range g.groups {
+		if option := child.findShort(name); option != nil {
+			return option
+		}
+	}
+
+	return nil
+}
+
+// findGroup returns the descendant group (searching recursively) whose
+// name matches name, or nil if none does.
+func (g *Group) findGroup(name string) *Group {
+	for _, child := range g.groups {
+		if child.name == name {
+			return child
+		}
+
+		if found := child.findGroup(name); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+// parseArgs applies command line flags, which take precedence over every
+// other source. Repeated slice/map flags accumulate into the option
+// rather than each occurrence replacing the last. For an option whose
+// effective MergeStrategy is MergeReplace (the default), the container is
+// additionally reset to empty on the first command line occurrence, so
+// CLI values replace (rather than append to) the default/config/env
+// value; under MergeAppend/MergeDeep, CLI occurrences are layered on top
+// of whatever the lower-priority sources already produced.
+//
+// opts governs three parser-wide behaviors: PassDoubleDash passes every
+// argument after a literal "--" straight through to the returned extra
+// slice without attempting to parse it as a flag; PassAfterNonOption does
+// the same starting from the first argument that isn't a flag at all;
+// IgnoreUnknown passes an unrecognized flag through as a plain argument
+// instead of returning an error. A match against the HelpFlag option's
+// -h/--help returns ErrHelp immediately.
+//
+// A bool option given without an explicit "=value" defaults to "true"
+// without consuming the next argument, so "--verbose input.txt" sets
+// Verbose and leaves input.txt as a positional argument.
+func (g *Group) parseArgs(args []string, sources map[string]string, defaultStrategy MergeStrategy, opts Options) ([]string, error) {
+	seen := map[string]bool{}
+	var extra []string
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if opts&PassDoubleDash != 0 && arg == "--" {
+			extra = append(extra, args[i+1:]...)
+			return extra, nil
+		}
+
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			if opts&PassAfterNonOption != 0 {
+				extra = append(extra, args[i:]...)
+				return extra, nil
+			}
+			extra = append(extra, arg)
+			continue
+		}
+
+		short := !strings.HasPrefix(arg, "--")
+		name := strings.TrimPrefix(arg, "--")
+		if short {
+			name = strings.TrimPrefix(arg, "-")
+		}
+
+		var value string
+		hasValue := false
+
+		if idx := strings.Index(name, "="); idx >= 0 {
+			value = name[idx+1:]
+			name = name[:idx]
+			hasValue = true
+		}
+
+		var option *Option
+		if short {
+			option = g.findShort(name)
+		} else {
+			option = g.findLong(name)
+		}
+
+		if option == nil {
+			if opts&IgnoreUnknown != 0 {
+				extra = append(extra, arg)
+				continue
+			}
+			return extra, fmt.Errorf("flags: unknown option %s", arg)
+		}
+
+		if option.isHelp {
+			return extra, ErrHelp
+		}
+
+		if !hasValue {
+			if option.value.Kind() == reflect.Bool {
+				value = "true"
+			} else {
+				if i+1 >= len(args) {
+					return extra, fmt.Errorf("flags: expected value for option %s", arg)
+				}
+				i++
+				value = args[i]
+			}
+		}
+
+		isContainer := option.value.Kind() == reflect.Slice || option.value.Kind() == reflect.Map
+		strategy := option.mergeStrategy(defaultStrategy)
+
+		if isContainer && !seen[option.Path()] && strategy == MergeReplace {
+			if option.value.Kind() == reflect.Slice {
+				option.value.Set(reflect.MakeSlice(option.value.Type(), 0, 0))
+			} else {
+				option.value.Set(reflect.MakeMap(option.value.Type()))
+			}
+		}
+		seen[option.Path()] = true
+
+		if isContainer && strategy != MergeReplace {
+			// Combine the occurrence like any other source, so MergeDeep
+			// recurses into a colliding map key's nested container.
+			if err := option.applyParts([]string{value}, strategy); err != nil {
+				return extra, err
+			}
+		} else if isContainer {
+			if err := appendInto(option.value, value); err != nil {
+				return extra, err
+			}
+		} else if err := option.applyString(value, strategy); err != nil {
+			return extra, err
+		}
+
+		sources[option.Path()] = "cli"
+	}
+
+	return extra, nil
+}
+
+// appendInto appends (slice) or inserts (map, "key:value") a single
+// command line occurrence into an already-initialized container value.
+// When a map's value type is itself a slice or map, the value half of
+// the pair recurses through buildValue instead of convertScalar.
+func appendInto(value reflect.Value, raw string) error {
+	switch value.Kind() {
+	case reflect.Slice:
+		item := reflect.New(value.Type().Elem()).Elem()
+		if err := convertScalar(item, raw); err != nil {
+			return err
+		}
+		value.Set(reflect.Append(value, item))
+		return nil
+	case reflect.Map:
+		kv := strings.SplitN(raw, ":", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("flags: invalid map entry %q, expected key:value", raw)
+		}
+
+		key := reflect.New(value.Type().Key()).Elem()
+		if err := convertScalar(key, kv[0]); err != nil {
+			return err
+		}
+
+		var val reflect.Value
+		if value.Type().Elem().Kind() == reflect.Slice || value.Type().Elem().Kind() == reflect.Map {
+			built, err := buildValue(value.Type().Elem(), []string{kv[1]})
+			if err != nil {
+				return err
+			}
+			val = built
+		} else {
+			val = reflect.New(value.Type().Elem()).Elem()
+			if err := convertScalar(val, kv[1]); err != nil {
+				return err
+			}
+		}
+
+		value.SetMapIndex(key, val)
+		return nil
+	default:
+		return convertScalar(value, raw)
+	}
+}
@@ -0,0 +1,154 @@
+package flags
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// ParseIniFile reads the ini-style config file at path and layers it into
+// the parser's precedence chain exactly like LoadConfigFile:
+//
+//	defaults < config file < env < command line
+//
+// Each "key=value" line is matched against an option by its `long` tag
+// (falling back to `json`, exactly like TestFallback). A line before any
+// "[section]" header applies to the root group; a "[name]" header
+// switches subsequent keys to the group or subcommand named name,
+// wherever it appears in the option tree, so "[scan]\nrate=1000"
+// populates the `scan` subcommand's --rate flag. Lines starting with ";"
+// or "#" are comments and are ignored, which lets a file written by
+// WriteIni (where default-sourced values are commented out) be read back
+// without those placeholders overriding anything. A slice/map option's
+// value is split on EnvDelim (defaulting to "," when unset, matching
+// WriteIni's own default), the same convention used to join it on write.
+// Requires the ConfigFileFallback option to have an effect during
+// ParseCommandLine.
+func (p *Parser) ParseIniFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("flags: failed to read ini file %s: %w", path, err)
+	}
+	defer f.Close()
+
+	config := make(map[string][]string)
+	group := p.Group
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.TrimSpace(line[1 : len(line)-1])
+
+			group = p.Group.findGroup(name)
+			if group == nil {
+				return fmt.Errorf("flags: failed to parse ini file %s: unknown section %q", path, name)
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return fmt.Errorf("flags: failed to parse ini file %s: invalid line %q, expected key=value", path, line)
+		}
+		key = strings.TrimSpace(key)
+
+		option := group.findOwnLong(key)
+		if option == nil {
+			return fmt.Errorf("flags: failed to parse ini file %s: unknown key %q", path, key)
+		}
+
+		config[option.Path()] = iniParts(option, strings.TrimSpace(value))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("flags: failed to parse ini file %s: %w", path, err)
+	}
+
+	p.config = config
+	return nil
+}
+
+// iniParts splits a raw ini value into the individual elements
+// Option.applyParts expects, for a slice/map option, on the same
+// delimiter WriteIni joins them with on write (EnvDelim, defaulting to
+// ","). Scalar options pass the value through unsplit.
+func iniParts(option *Option, raw string) []string {
+	if option.value.Kind() != reflect.Slice && option.value.Kind() != reflect.Map {
+		return []string{raw}
+	}
+
+	delim := option.EnvDelim
+	if delim == "" {
+		delim = ","
+	}
+
+	return strings.Split(raw, delim)
+}
+
+// WriteIni serializes the parser's current (effective) option values to w
+// in ini format, the diff-friendly counterpart to WriteConfigFile: each
+// group/subcommand becomes a "[name]" section, each option's `description`
+// tag (if any) is emitted as a preceding "; " comment, and a value that is
+// still just the compiled-in default (as opposed to one supplied by a
+// config file, the environment or the command line) is itself written
+// commented out, e.g. ";rate=100", so the file documents every available
+// key without claiming the operator set it. WriteIni reflects the sources
+// recorded by the most recent call to ParseCommandLine. An option resolved
+// via env-file indirection (see Option.secret) is written as
+// secretPlaceholder instead of its real value when the parser's
+// SecretRedact option is set.
+func (p *Parser) WriteIni(w io.Writer) error {
+	return writeIniGroup(w, p.Group, p.sources, p.Options&SecretRedact != 0, true)
+}
+
+func writeIniGroup(w io.Writer, g *Group, sources map[string]string, redact bool, root bool) error {
+	if !root {
+		if _, err := fmt.Fprintf(w, "\n[%s]\n", g.name); err != nil {
+			return err
+		}
+	}
+
+	for _, option := range g.options {
+		key := option.longOrJSON()
+		if key == "" {
+			continue
+		}
+
+		if option.Description != "" {
+			if _, err := fmt.Fprintf(w, "; %s\n", option.Description); err != nil {
+				return err
+			}
+		}
+
+		value := secretPlaceholder
+		if !(redact && option.secret) {
+			value = joinWithDelim(stringifyValue(option.value, option.EnvDelim), option.EnvDelim)
+		}
+
+		prefix := ""
+		if source := sources[option.Path()]; source == "" || source == "default" {
+			prefix = ";"
+		}
+
+		if _, err := fmt.Fprintf(w, "%s%s=%s\n", prefix, key, value); err != nil {
+			return err
+		}
+	}
+
+	for _, child := range g.groups {
+		if err := writeIniGroup(w, child, sources, redact, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package flags
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestIniFilePrecedence checks that the command line overrides an ini
+// config file for a root option, and that an ini subcommand section
+// overrides the compiled-in default of that subcommand's option.
+func TestIniFilePrecedence(t *testing.T) {
+	type Scan struct {
+		Rate int `long:"rate" default:"10"`
+	}
+
+	type Options struct {
+		Int  int `long:"int" json:"json-int" default:"1"`
+		Scan Scan
+	}
+
+	contents := "int=5\n" +
+		"\n" +
+		"[scan]\n" +
+		"rate=1000\n"
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.ini")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("failed to write ini fixture: %v", err)
+	}
+
+	var opts Options
+	p := NewParser(&opts, Default|ConfigFileFallback)
+
+	if err := p.ParseIniFile(path); err != nil {
+		t.Fatalf("ParseIniFile: %v", err)
+	}
+
+	if _, _, _, err := p.ParseCommandLine([]string{"--int=8"}); err != nil {
+		t.Fatalf("ParseCommandLine: %v", err)
+	}
+
+	if opts.Int != 8 {
+		t.Errorf("expected CLI to override ini config, got Int=%d", opts.Int)
+	}
+	if opts.Scan.Rate != 1000 {
+		t.Errorf("expected ini section to populate scan.rate, got %d", opts.Scan.Rate)
+	}
+}
+
+// TestWriteIni checks that WriteIni emits a description comment for each
+// option, sections for subcommands, and comments out values that are
+// still just the compiled-in default.
+func TestWriteIni(t *testing.T) {
+	type Scan struct {
+		Rate int `long:"rate" default:"100" description:"Packets per second"`
+	}
+
+	type Options struct {
+		Name string `long:"name" description:"Operator-facing run name"`
+		Scan Scan
+	}
+
+	var opts Options
+	opts.Name = "prod"
+
+	p := NewParser(&opts, Default)
+	if _, _, _, err := p.ParseCommandLine([]string{"--name=prod"}); err != nil {
+		t.Fatalf("ParseCommandLine: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := p.WriteIni(&buf); err != nil {
+		t.Fatalf("WriteIni: %v", err)
+	}
+
+	out := buf.String()
+
+	if !strings.Contains(out, "; Operator-facing run name\nname=prod") {
+		t.Errorf("expected a CLI-sourced value written uncommented, got:\n%s", out)
+	}
+	if !strings.Contains(out, "[scan]") {
+		t.Errorf("expected a [scan] section, got:\n%s", out)
+	}
+	if !strings.Contains(out, "; Packets per second\n;rate=100") {
+		t.Errorf("expected a default-sourced value written commented out, got:\n%s", out)
+	}
+}
+
+// TestWriteIniRoundTrip checks that ParseIniFile can read back a file
+// produced by WriteIni, skipping the commented-out default placeholders.
+func TestWriteIniRoundTrip(t *testing.T) {
+	type Options struct {
+		Int   int            `long:"int" default:"1"`
+		Slice []int          `long:"slice"`
+		Map   map[string]int `long:"map"`
+	}
+
+	var opts Options
+	opts.Int = 42
+	opts.Slice = []int{3, 4, 5}
+	opts.Map = map[string]int{"a": 9, "b": 2}
+
+	writer := NewParser(&opts, Default)
+	if _, _, _, err := writer.ParseCommandLine([]string{"--int=42", "--slice=3", "--slice=4", "--slice=5", "--map=a:9", "--map=b:2"}); err != nil {
+		t.Fatalf("ParseCommandLine: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := writer.WriteIni(&buf); err != nil {
+		t.Fatalf("WriteIni: %v", err)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.ini")
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatalf("failed to write round-trip fixture: %v", err)
+	}
+
+	var readBack Options
+	reader := NewParser(&readBack, Default|ConfigFileFallback)
+	if err := reader.ParseIniFile(path); err != nil {
+		t.Fatalf("ParseIniFile: %v", err)
+	}
+	if _, _, _, err := reader.ParseCommandLine(nil); err != nil {
+		t.Fatalf("ParseCommandLine: %v", err)
+	}
+
+	if readBack.Int != opts.Int || !reflect.DeepEqual(readBack.Slice, opts.Slice) || !reflect.DeepEqual(readBack.Map, opts.Map) {
+		t.Errorf("round-trip mismatch: wrote %+v, read back %+v", opts, readBack)
+	}
+}
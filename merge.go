@@ -0,0 +1,90 @@
+package flags
+
+import "reflect"
+
+// MergeStrategy controls how a slice or map option's value from one
+// source (default/config/env/CLI) is combined with the value already
+// produced by lower-priority sources.
+type MergeStrategy int
+
+const (
+	// MergeReplace discards the previous value entirely; this is the
+	// historical zflags behavior and remains the default.
+	MergeReplace MergeStrategy = iota
+
+	// MergeAppend concatenates slices, and for maps adds/overwrites keys
+	// from the new source without recursing into nested containers.
+	MergeAppend
+
+	// MergeDeep behaves like MergeAppend, but additionally recurses when
+	// both the previous and new value for a given map key are
+	// maps (or both are slices), rather than having the new value win
+	// outright.
+	MergeDeep
+)
+
+// mergeStrategy returns the strategy that should be used to combine
+// values for this option: its own `merge:"..."` tag if set, otherwise
+// the parser-wide default.
+func (option *Option) mergeStrategy(def MergeStrategy) MergeStrategy {
+	switch option.Merge {
+	case "append":
+		return MergeAppend
+	case "deep":
+		return MergeDeep
+	case "replace":
+		return MergeReplace
+	default:
+		return def
+	}
+}
+
+// mergeContainers combines src (from a higher-priority source) into dst
+// (the value accumulated so far from lower-priority sources) according
+// to strategy, returning the combined value. Scalars, and any value under
+// MergeReplace, simply hand back src: the new source wins outright. On a
+// container collision under MergeDeep, corresponding nested maps/slices
+// are merged recursively instead of one replacing the other.
+func mergeContainers(dst, src reflect.Value, strategy MergeStrategy) reflect.Value {
+	if strategy == MergeReplace {
+		return src
+	}
+
+	switch src.Kind() {
+	case reflect.Slice:
+		if dst.Kind() != reflect.Slice {
+			return src
+		}
+		return reflect.AppendSlice(dst, src)
+	case reflect.Map:
+		if dst.Kind() != reflect.Map {
+			return src
+		}
+
+		out := reflect.MakeMap(src.Type())
+		for _, key := range dst.MapKeys() {
+			out.SetMapIndex(key, dst.MapIndex(key))
+		}
+
+		for _, key := range src.MapKeys() {
+			srcVal := src.MapIndex(key)
+
+			if strategy == MergeDeep {
+				if existing := out.MapIndex(key); existing.IsValid() &&
+					(existing.Kind() == reflect.Map || existing.Kind() == reflect.Slice) &&
+					existing.Kind() == srcVal.Kind() {
+					out.SetMapIndex(key, mergeContainers(existing, srcVal, strategy))
+					continue
+				}
+			}
+
+			// Scalar collision (or MergeAppend): the higher priority
+			// source wins.
+			out.SetMapIndex(key, srcVal)
+		}
+
+		return out
+	default:
+		return src
+	}
+}
@@ -0,0 +1,101 @@
+package flags
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+// TestMergeStrategies checks that `merge:"deep"`/`merge:"append"` combine
+// map/slice values contributed by each source instead of each source
+// wholesale replacing the last, per the precedence order already
+// exercised (without merging) by TestFallback.
+func TestMergeStrategies(t *testing.T) {
+	type Options struct {
+		AppendSlice []int          `long:"append-slice" default:"1" default:"2" env:"APPEND_SLICE" env-delim:"," merge:"append"`
+		DeepMap     map[string]int `long:"deep-map" default:"a:1" env:"DEEP_MAP" env-delim:";" merge:"deep"`
+		ReplaceMap  map[string]int `long:"replace-map" default:"a:1" env:"REPLACE_MAP" env-delim:";"`
+	}
+
+	var tests = []struct {
+		msg      string
+		args     []string
+		env      map[string]string
+		expected Options
+	}{
+		{
+			msg: "defaults only",
+			expected: Options{
+				AppendSlice: []int{1, 2},
+				DeepMap:     map[string]int{"a": 1},
+				ReplaceMap:  map[string]int{"a": 1},
+			},
+		},
+		{
+			msg:  "env and CLI values concatenate/deep-merge onto defaults",
+			args: []string{"--append-slice=5", "--deep-map=b:9"},
+			env: map[string]string{
+				"APPEND_SLICE": "3,4",
+				"DEEP_MAP":     "a:2;c:3",
+				"REPLACE_MAP":  "b:2",
+			},
+			expected: Options{
+				// default [1,2] ++ env [3,4] ++ cli [5]
+				AppendSlice: []int{1, 2, 3, 4, 5},
+				// default {a:1} deep-merged with env {a:2,c:3} (a overwritten)
+				// then CLI {b:9} merged in
+				DeepMap: map[string]int{"a": 2, "b": 9, "c": 3},
+				// no merge tag -> replace semantics, like TestFallback
+				ReplaceMap: map[string]int{"b": 2},
+			},
+		},
+	}
+
+	oldEnv := EnvSnapshot()
+	defer oldEnv.Restore()
+
+	for _, test := range tests {
+		oldEnv.Restore()
+		for envKey, envValue := range test.env {
+			os.Setenv(envKey, envValue)
+		}
+
+		var opts Options
+		_, _, _, err := NewParser(&opts, Default).ParseCommandLine(test.args)
+		if err != nil {
+			t.Fatalf("%s:\nUnexpected error: %v", test.msg, err)
+		}
+
+		if !reflect.DeepEqual(opts, test.expected) {
+			t.Errorf("%s:\nexpected\n%+v\nbut got\n%+v", test.msg, test.expected, opts)
+		}
+	}
+}
+
+// TestMergeMapOfSlices checks that merge:"deep" also works on a
+// map[string][]int option, where each source contributes a "key:value"
+// pair whose value half is itself a slice, recursing instead of erroring
+// out as an unsupported option type.
+func TestMergeMapOfSlices(t *testing.T) {
+	type Options struct {
+		DeepMapOfSlices map[string][]int `long:"deep-map-of-slices" default:"a:1" env:"DEEP_MAP_OF_SLICES" env-delim:";" merge:"deep"`
+	}
+
+	oldEnv := EnvSnapshot()
+	defer oldEnv.Restore()
+	oldEnv.Restore()
+	os.Setenv("DEEP_MAP_OF_SLICES", "a:2;b:3")
+
+	var opts Options
+	_, _, _, err := NewParser(&opts, Default).ParseCommandLine([]string{"--deep-map-of-slices=c:4"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// default {a:[1]} deep-merged with env {a:[2],b:[3]} (a concatenated)
+	// then CLI {c:[4]} merged in (new key)
+	expected := map[string][]int{"a": {1, 2}, "b": {3}, "c": {4}}
+	if !reflect.DeepEqual(opts.DeepMapOfSlices, expected) {
+		t.Errorf("expected %+v, got %+v", expected, opts.DeepMapOfSlices)
+	}
+}
@@ -0,0 +1,274 @@
+package flags
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// Option represents a single struct field exposed as a command line flag,
+// environment variable and/or config file key.
+type Option struct {
+	// LongName is the long flag name (`long` tag), e.g. "port" for --port.
+	LongName string
+
+	// ShortName is the short flag name (`short` tag), e.g. "p" for -p.
+	ShortName string
+
+	// Json is the key used for config file loading and, when LongName is
+	// empty, as the long flag name fallback.
+	Json string
+
+	// EnvKeyName is the explicit `env` tag, if any.
+	EnvKeyName string
+
+	// EnvFileKeyName is the explicit `env-file` tag, if any: the name of
+	// an environment variable whose value is a path to a file holding the
+	// option's real value. When absent but `env` is present, the
+	// "<env>_FILE" convention is consulted automatically; see envFileKey.
+	EnvFileKeyName string
+
+	// EnvDelim splits a scalar env/config string into slice/map elements.
+	EnvDelim string
+
+	// Default holds the raw `default` tag value(s), applied before any
+	// other source is consulted.
+	Default []string
+
+	// Merge is the raw `merge` tag ("deep", "append" or "replace"); an
+	// empty string means the parser-wide MergeStrategy applies. See
+	// mergeStrategy and mergeContainers.
+	Merge string
+
+	// Description is the `description` tag, shown in --help output.
+	Description string
+
+	// Validate is the raw `validate:"..."` tag, a comma-separated list of
+	// "name" or "name=param" entries consulted by Parser.ParseCommandLine
+	// once every other source has been applied. See the Validator type.
+	Validate string
+
+	group *Group
+	field reflect.StructField
+	value reflect.Value
+
+	// secret records whether this option's current value was resolved
+	// through env-file indirection, so it can be withheld from error
+	// output, as well as from WriteConfigFile/WriteIni dumps, when
+	// the parser's SecretRedact option is set. See secretPlaceholder.
+	secret bool
+
+	// isHelp marks the synthetic -h/--help option added by newHelpGroup
+	// when the HelpFlag parser option is set; matching it on the command
+	// line short-circuits parseArgs with ErrHelp instead of assigning a
+	// value.
+	isHelp bool
+}
+
+// secretPlaceholder replaces a secret-sourced option's value (see
+// Option.secret) in WriteConfigFile/WriteIni output when the parser's
+// SecretRedact option is set, so a value loaded through env-file
+// indirection is never echoed back out in plaintext.
+const secretPlaceholder = "***"
+
+// newHelpGroup builds the "Help Options" group added to a Parser's root
+// group when HelpFlag is set, containing the -h/--help flag.
+func newHelpGroup() *Group {
+	return &Group{
+		name: "Help Options",
+		options: []*Option{
+			{
+				LongName:    "help",
+				ShortName:   "h",
+				Description: "Show this help message",
+				value:       reflect.New(reflect.TypeOf(false)).Elem(),
+				isHelp:      true,
+			},
+		},
+	}
+}
+
+// Path returns the dotted path used to key this option in precedence maps
+// such as the ones returned from Parser.ParseCommandLine, e.g.
+// "scan.rate" for the `rate` option of a `scan` group/command.
+func (option *Option) Path() string {
+	if option.group == nil || option.group.name == "" {
+		return option.longOrJSON()
+	}
+
+	return option.group.name + "." + option.longOrJSON()
+}
+
+// longOrJSON returns the `long` tag, falling back to the `json` tag when
+// `long` is absent.
+func (option *Option) longOrJSON() string {
+	if option.LongName != "" {
+		return option.LongName
+	}
+
+	return option.Json
+}
+
+// envKey returns the primary environment variable name consulted for
+// this option, for reporting in ParseCommandLine's missingEnv list: the
+// first entry of envCandidates, or "" if none apply. See envCandidates
+// for the full ordered list actually checked against the environment.
+func (option *Option) envKey(fallbackEnabled bool) string {
+	candidates := option.envCandidates(fallbackEnabled)
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	return candidates[0]
+}
+
+// envCandidates returns, in priority order, every environment variable
+// name consulted for this option. An explicit `env` tag is consulted on
+// its own, regardless of the parser's EnvironmentFallback option, since
+// the operator named it explicitly. Absent that, fallbackEnabled (set
+// from EnvironmentFallback) gates trying `long`, then `json`, then the
+// struct field's literal Go name, mirroring the long/json fallback
+// already used for CLI flags and config files.
+func (option *Option) envCandidates(fallbackEnabled bool) []string {
+	if option.EnvKeyName != "" {
+		return []string{option.EnvKeyName}
+	}
+
+	if !fallbackEnabled {
+		return nil
+	}
+
+	var keys []string
+	if option.LongName != "" {
+		keys = append(keys, option.LongName)
+	}
+	if option.Json != "" {
+		keys = append(keys, option.Json)
+	}
+	if option.field.Name != "" {
+		keys = append(keys, option.field.Name)
+	}
+
+	return keys
+}
+
+// applyDefault resets the option to its compiled-in `default` tag
+// value(s), combined according to strategy (a no-op the first time,
+// since option.value starts out zero).
+func (option *Option) applyDefault(strategy MergeStrategy) error {
+	if len(option.Default) == 0 {
+		return nil
+	}
+
+	return option.applyParts(option.Default, strategy)
+}
+
+// envFileKey returns the environment variable expected to hold a path to
+// a file containing this option's real value: the explicit `env-file`
+// tag if present, otherwise the "<env>_FILE" convention derived from the
+// explicit `env` tag (e.g. an option with `env:"DB_PASSWORD"` is also
+// resolved from $DB_PASSWORD_FILE). Unlike the plain `env` variable, this
+// convention is never derived from `long`/`json`/the field name, since it
+// would otherwise trigger file-indirection for options whose operator
+// never named an environment variable at all.
+func (option *Option) envFileKey() string {
+	if option.EnvFileKeyName != "" {
+		return option.EnvFileKeyName
+	}
+
+	if option.EnvKeyName != "" {
+		return option.EnvKeyName + "_FILE"
+	}
+
+	return ""
+}
+
+// applyEnv resolves the option's value from the environment, returning
+// the source it was resolved from ("env-file" or "env") and whether any
+// applicable environment variable was set. `env-file` (or its "<env>_FILE"
+// convention) takes priority over the plain `env` variable. An explicit
+// `env` tag is consulted regardless of fallbackEnabled; fallbackEnabled
+// additionally allows resolving from `long`, `json` or the struct field's
+// Go name when no explicit `env` tag is present (see envCandidates).
+// When redact is true, any error produced while resolving an env-file
+// value is replaced with a generic message that omits the file path and
+// the underlying error text, either of which could otherwise leak the
+// secret into logs or --help/error output.
+func (option *Option) applyEnv(strategy MergeStrategy, fallbackEnabled bool, redact bool) (string, bool, error) {
+	if fileKey := option.envFileKey(); fileKey != "" {
+		if path, ok := os.LookupEnv(fileKey); ok {
+			option.secret = true
+
+			raw, err := os.ReadFile(path)
+			if err != nil {
+				if redact {
+					return "", false, fmt.Errorf("flags: failed to resolve secret value for option %s", option.Path())
+				}
+				return "", false, fmt.Errorf("flags: failed to read %s=%s for option %s: %w", fileKey, path, option.Path(), err)
+			}
+
+			if err := option.applyString(trimTrailingNewline(string(raw)), strategy); err != nil {
+				if redact {
+					return "", false, fmt.Errorf("flags: failed to resolve secret value for option %s", option.Path())
+				}
+				return "", false, err
+			}
+
+			return "env-file", true, nil
+		}
+	}
+
+	for _, key := range option.envCandidates(fallbackEnabled) {
+		raw, ok := os.LookupEnv(key)
+		if !ok {
+			continue
+		}
+
+		return "env", true, option.applyString(raw, strategy)
+	}
+
+	return "", false, nil
+}
+
+// trimTrailingNewline strips a single trailing "\n" and then a single
+// trailing "\r" (so a Windows "\r\n" ending goes as a whole) from
+// file-sourced values, mirroring how most secret-mount tooling writes
+// files.
+func trimTrailingNewline(s string) string {
+	s = strings.TrimSuffix(s, "\n")
+	s = strings.TrimSuffix(s, "\r")
+	return s
+}
+
+// applyString applies a single scalar string value to the option,
+// splitting on EnvDelim first when the option is a slice or map.
+func (option *Option) applyString(raw string, strategy MergeStrategy) error {
+	var parts []string
+
+	if option.EnvDelim != "" && (option.value.Kind() == reflect.Slice || option.value.Kind() == reflect.Map) {
+		parts = strings.Split(raw, option.EnvDelim)
+	} else {
+		parts = []string{raw}
+	}
+
+	return option.applyParts(parts, strategy)
+}
+
+// applyParts converts parts into this option's type and combines the
+// result with whatever value a lower-priority source already produced,
+// according to strategy. For non-slice/map options this simply
+// overwrites the value, as there is nothing meaningful to merge.
+func (option *Option) applyParts(parts []string, strategy MergeStrategy) error {
+	built, err := buildValue(option.value.Type(), parts)
+	if err != nil {
+		return err
+	}
+
+	if option.value.Kind() == reflect.Slice || option.value.Kind() == reflect.Map {
+		built = mergeContainers(option.value, built, strategy)
+	}
+
+	option.value.Set(built)
+	return nil
+}
@@ -0,0 +1,193 @@
+package flags
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrHelp is returned by Parser.ParseCommandLine when the command line
+// matches the HelpFlag option's -h/--help flag, so callers can print
+// usage and exit rather than treating it as an ordinary parse failure.
+var ErrHelp = errors.New("flags: help requested")
+
+// Options provides parser options that change the behavior of the parser.
+type Options uint
+
+const (
+	// None indicates no options.
+	None Options = 0
+
+	// HelpFlag adds a default Help Options group to the parser containing
+	// a -h/--help option.
+	HelpFlag = 1 << iota
+
+	// PassDoubleDash passes all arguments after a double dash, --, as
+	// remaining command line arguments (i.e. they will not be parsed
+	// for flags).
+	PassDoubleDash
+
+	// PassAfterNonOption passes all arguments after the first non option
+	// as remaining command line arguments.
+	PassAfterNonOption
+
+	// IgnoreUnknown ignores any unknown options and passes them as
+	// remaining command line arguments instead of generating an error.
+	IgnoreUnknown
+
+	// EnvironmentFallback enables resolving option values from an
+	// environment variable name inferred from the option's `long` tag,
+	// then its `json` tag, then its struct field name, when the option
+	// carries no explicit `env` tag. An explicit `env` tag is always
+	// consulted, with or without this option set; EnvironmentFallback
+	// only governs the inferred names. See Option.envCandidates.
+	EnvironmentFallback
+
+	// ConfigFileFallback enables resolving option values from a structured
+	// (JSON or YAML) config file loaded via Parser.LoadConfigFile, before
+	// falling back to the environment and after the compiled-in defaults.
+	// See LoadConfigFile for the full precedence chain.
+	ConfigFileFallback
+
+	// SecretRedact withholds the resolved value of any option populated
+	// via env-file indirection (an `env-file` tag or the automatic
+	// "<env>_FILE" convention) from error messages and from
+	// WriteConfigFile/WriteIni output, printing a placeholder instead.
+	// See Option.envFileKey.
+	SecretRedact
+
+	// Default is a convenient set of options that should cover most of the
+	// use cases.
+	Default = HelpFlag | PassDoubleDash | EnvironmentFallback
+)
+
+// Parser is the main parser type. It contains the actual parser logic and
+// the group of options being parsed, along with any registered config or
+// validation state.
+type Parser struct {
+	// Options controls the overall behavior of the parser.
+	Options Options
+
+	// Group is the root option group, built by reflecting over the struct
+	// passed to NewParser.
+	Group *Group
+
+	// config holds the values loaded by LoadConfigFile or ParseIniFile,
+	// keyed by the dotted path to each option (see Option.Path). Each
+	// value is already split into the individual elements a slice/map
+	// option expects (one per JSON array/object entry, or split on
+	// EnvDelim for ini), ready to hand to Option.applyParts without
+	// re-joining and re-splitting through a delimited string. It sits
+	// between the compiled-in defaults and the environment in the
+	// precedence chain.
+	config map[string][]string
+
+	// MergeStrategy is the default strategy used to combine a slice/map
+	// option's values across defaults/config/env/CLI when the option
+	// itself carries no `merge:"..."` tag. It defaults to MergeReplace,
+	// preserving the historical behavior where each source's value
+	// entirely replaces the previous one.
+	MergeStrategy MergeStrategy
+
+	// ValidationErrors holds every failure from the most recent
+	// ParseCommandLine's `validate:"..."` pass, one per failed entry, so
+	// callers can surface each misconfigured option rather than just the
+	// first. It is reset on every call to ParseCommandLine.
+	ValidationErrors []error
+
+	// validators holds validators registered via RegisterValidator,
+	// consulted ahead of builtinValidators for the same name.
+	validators map[string]Validator
+
+	// sources holds the per-option sources map produced by the most
+	// recent ParseCommandLine, so WriteIni can tell a default-sourced
+	// value apart from one an operator actually supplied.
+	sources map[string]string
+}
+
+// NewParser creates a new parser for the given data, which must be a
+// pointer to a struct. Each field of the struct (recursively, for nested
+// struct fields representing groups or commands) becomes an option.
+func NewParser(data interface{}, options Options) *Parser {
+	v := reflect.ValueOf(data)
+
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("flags: NewParser expects a pointer to a struct, got %T", data))
+	}
+
+	p := &Parser{
+		Options: options,
+	}
+
+	p.Group = newGroup("", v.Elem())
+
+	if options&HelpFlag != 0 {
+		p.Group.groups = append(p.Group.groups, newHelpGroup())
+	}
+
+	return p
+}
+
+// ParseCommandLine parses the given command line arguments, applying
+// values from (in increasing order of priority) compiled-in defaults, a
+// previously loaded config file (ConfigFileFallback), the environment
+// (EnvironmentFallback) and finally the command line itself. Once every
+// source has been applied, any `validate:"..."` tags are checked; a
+// failure there is reported via ValidationErrors and the returned error,
+// but does not prevent the struct from holding the resolved values.
+//
+// It returns any unparsed (non-option) trailing arguments, a map from the
+// dotted option path to the source that ultimately supplied its value,
+// the names of any environment variables that were consulted but unset,
+// and an error, if parsing or validation failed.
+func (p *Parser) ParseCommandLine(args []string) (extra []string, sources map[string]string, missingEnv []string, err error) {
+	sources = make(map[string]string)
+	p.ValidationErrors = nil
+
+	if walkErr := p.Group.walk(func(opt *Option) error {
+		strategy := opt.mergeStrategy(p.MergeStrategy)
+
+		if applyErr := opt.applyDefault(strategy); applyErr != nil {
+			return applyErr
+		}
+		sources[opt.Path()] = "default"
+
+		if p.Options&ConfigFileFallback != 0 {
+			if parts, ok := p.config[opt.Path()]; ok {
+				if applyErr := opt.applyParts(parts, strategy); applyErr != nil {
+					return applyErr
+				}
+				sources[opt.Path()] = "config"
+			}
+		}
+
+		fallbackEnabled := p.Options&EnvironmentFallback != 0
+
+		source, ok, applyErr := opt.applyEnv(strategy, fallbackEnabled, p.Options&SecretRedact != 0)
+		if applyErr != nil {
+			return applyErr
+		}
+		if ok {
+			sources[opt.Path()] = source
+		} else if key := opt.envKey(fallbackEnabled); key != "" {
+			missingEnv = append(missingEnv, key)
+		}
+
+		return nil
+	}); walkErr != nil {
+		return nil, sources, missingEnv, walkErr
+	}
+
+	extra, err = p.Group.parseArgs(args, sources, p.MergeStrategy, p.Options)
+	p.sources = sources
+	if err != nil {
+		return extra, sources, missingEnv, err
+	}
+
+	p.ValidationErrors = p.validate()
+	if len(p.ValidationErrors) > 0 {
+		return extra, sources, missingEnv, &ValidationError{Errors: p.ValidationErrors}
+	}
+
+	return extra, sources, missingEnv, nil
+}
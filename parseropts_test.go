@@ -0,0 +1,144 @@
+package flags
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestHelpFlag checks that HelpFlag adds a -h/--help option that returns
+// ErrHelp instead of being treated as an unknown option or a regular
+// value-carrying flag.
+func TestHelpFlag(t *testing.T) {
+	type Options struct {
+		Name string `long:"name"`
+	}
+
+	for _, flag := range []string{"--help", "-h"} {
+		var opts Options
+		_, _, _, err := NewParser(&opts, Default).ParseCommandLine([]string{flag})
+		if !errors.Is(err, ErrHelp) {
+			t.Errorf("%s: expected ErrHelp, got %v", flag, err)
+		}
+	}
+
+	var withoutFlag Options
+	if _, _, _, err := NewParser(&withoutFlag, None).ParseCommandLine([]string{"--help"}); err == nil || errors.Is(err, ErrHelp) {
+		t.Errorf("expected an unknown-option error with HelpFlag unset, got %v", err)
+	}
+}
+
+// TestShortName checks that a `short:"x"` tag is recognized as -x on the
+// command line, standing in for its `long` name.
+func TestShortName(t *testing.T) {
+	type Options struct {
+		Port int `long:"port" short:"p" default:"80"`
+	}
+
+	var tests = []struct {
+		msg      string
+		args     []string
+		expected int
+	}{
+		{msg: "short flag with separate value", args: []string{"-p", "8080"}, expected: 8080},
+		{msg: "short flag with =value", args: []string{"-p=9090"}, expected: 9090},
+		{msg: "long flag still works", args: []string{"--port", "7070"}, expected: 7070},
+	}
+
+	for _, test := range tests {
+		var opts Options
+		_, _, _, err := NewParser(&opts, Default).ParseCommandLine(test.args)
+		if err != nil {
+			t.Fatalf("%s:\nUnexpected error: %v", test.msg, err)
+		}
+		if opts.Port != test.expected {
+			t.Errorf("%s:\nexpected Port=%d, got %d", test.msg, test.expected, opts.Port)
+		}
+	}
+}
+
+// TestBoolFlag checks that a bool option given without an explicit
+// "=value" defaults to true and does not consume the next argument,
+// leaving it available as a positional argument.
+func TestBoolFlag(t *testing.T) {
+	type Options struct {
+		Verbose bool `long:"verbose"`
+	}
+
+	var opts Options
+	extra, _, _, err := NewParser(&opts, Default).ParseCommandLine([]string{"--verbose", "input.txt"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !opts.Verbose {
+		t.Errorf("expected Verbose=true, got false")
+	}
+	if !reflect.DeepEqual(extra, []string{"input.txt"}) {
+		t.Errorf("expected extra to hold the untouched positional argument, got %v", extra)
+	}
+}
+
+// TestPassDoubleDash checks that arguments after a literal "--" are
+// returned as extra arguments rather than parsed as flags.
+func TestPassDoubleDash(t *testing.T) {
+	type Options struct {
+		Name string `long:"name"`
+	}
+
+	var opts Options
+	extra, _, _, err := NewParser(&opts, Default).ParseCommandLine([]string{"--name=x", "--", "--name=y", "pos"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if opts.Name != "x" {
+		t.Errorf("expected Name=x (pre \"--\"), got %q", opts.Name)
+	}
+	if !reflect.DeepEqual(extra, []string{"--name=y", "pos"}) {
+		t.Errorf("expected extra to hold everything after \"--\", got %v", extra)
+	}
+}
+
+// TestPassAfterNonOption checks that the first non-flag argument stops
+// flag parsing and dumps everything from there into extra.
+func TestPassAfterNonOption(t *testing.T) {
+	type Options struct {
+		Name string `long:"name"`
+	}
+
+	var opts Options
+	extra, _, _, err := NewParser(&opts, Default|PassAfterNonOption).ParseCommandLine([]string{"--name=x", "pos", "--name=y"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if opts.Name != "x" {
+		t.Errorf("expected Name=x (set before the non-option argument), got %q", opts.Name)
+	}
+	if !reflect.DeepEqual(extra, []string{"pos", "--name=y"}) {
+		t.Errorf("expected extra to hold everything from the first non-option argument on, got %v", extra)
+	}
+}
+
+// TestIgnoreUnknown checks that an unrecognized flag is passed through as
+// a plain argument instead of erroring when IgnoreUnknown is set.
+func TestIgnoreUnknown(t *testing.T) {
+	type Options struct {
+		Name string `long:"name"`
+	}
+
+	var withIgnore Options
+	extra, _, _, err := NewParser(&withIgnore, Default|IgnoreUnknown).ParseCommandLine([]string{"--name=x", "--bogus=y"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if withIgnore.Name != "x" {
+		t.Errorf("expected Name=x, got %q", withIgnore.Name)
+	}
+	if !reflect.DeepEqual(extra, []string{"--bogus=y"}) {
+		t.Errorf("expected the unknown flag to pass through as extra, got %v", extra)
+	}
+
+	var withoutIgnore Options
+	if _, _, _, err := NewParser(&withoutIgnore, Default).ParseCommandLine([]string{"--bogus=y"}); err == nil {
+		t.Error("expected an error for an unknown flag without IgnoreUnknown")
+	}
+}
@@ -0,0 +1,198 @@
+package flags
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestEnvFileSecret checks the `env-file:"..."` tag, the automatic
+// "<env>_FILE" convention, and their precedence against the plain `env`
+// variable and CLI flag.
+func TestEnvFileSecret(t *testing.T) {
+	dir := t.TempDir()
+
+	explicitPath := filepath.Join(dir, "explicit")
+	if err := os.WriteFile(explicitPath, []byte("from-explicit-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	conventionPath := filepath.Join(dir, "convention")
+	if err := os.WriteFile(conventionPath, []byte("from-convention-file\r\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	type Options struct {
+		Explicit   string `long:"explicit" env-file:"EXPLICIT_FILE_VAR" env:"EXPLICIT"`
+		Convention string `long:"convention" env:"CONVENTION"`
+	}
+
+	var tests = []struct {
+		msg        string
+		args       []string
+		env        map[string]string
+		expected   Options
+		checkPath  string
+		wantSource string
+	}{
+		{
+			msg: "env-file tag is read and trimmed",
+			env: map[string]string{
+				"EXPLICIT_FILE_VAR": explicitPath,
+			},
+			expected:   Options{Explicit: "from-explicit-file"},
+			checkPath:  "explicit",
+			wantSource: "env-file",
+		},
+		{
+			msg: "CONVENTION_FILE is consulted automatically for an env tag",
+			env: map[string]string{
+				"CONVENTION_FILE": conventionPath,
+			},
+			expected:   Options{Convention: "from-convention-file"},
+			checkPath:  "convention",
+			wantSource: "env-file",
+		},
+		{
+			msg: "env-file takes priority over the plain env variable",
+			env: map[string]string{
+				"EXPLICIT_FILE_VAR": explicitPath,
+				"EXPLICIT":          "from-env",
+			},
+			expected:   Options{Explicit: "from-explicit-file"},
+			checkPath:  "explicit",
+			wantSource: "env-file",
+		},
+		{
+			msg:  "CLI takes priority over env-file",
+			args: []string{"--explicit=from-cli"},
+			env: map[string]string{
+				"EXPLICIT_FILE_VAR": explicitPath,
+			},
+			expected:   Options{Explicit: "from-cli"},
+			checkPath:  "explicit",
+			wantSource: "cli",
+		},
+	}
+
+	oldEnv := EnvSnapshot()
+	defer oldEnv.Restore()
+
+	for _, test := range tests {
+		var opts Options
+		oldEnv.Restore()
+		for envKey, envValue := range test.env {
+			os.Setenv(envKey, envValue)
+		}
+
+		_, sources, _, err := NewParser(&opts, Default|EnvironmentFallback).ParseCommandLine(test.args)
+		if err != nil {
+			t.Fatalf("%s:\nUnexpected error: %v", test.msg, err)
+		}
+
+		if opts != test.expected {
+			t.Errorf("%s:\nexpected %+v, got %+v", test.msg, test.expected, opts)
+		}
+
+		if got := sources[test.checkPath]; got != test.wantSource {
+			t.Errorf("%s:\nexpected source %q for %s, got %q", test.msg, test.wantSource, test.checkPath, got)
+		}
+	}
+}
+
+// TestEnvFileMissingFile checks that a missing env-file path is reported
+// as a normal error, and that SecretRedact replaces it with a generic
+// message that withholds the file path.
+func TestEnvFileMissingFile(t *testing.T) {
+	type Options struct {
+		Password string `long:"password" env:"PASSWORD"`
+	}
+
+	oldEnv := EnvSnapshot()
+	defer oldEnv.Restore()
+
+	missingPath := filepath.Join(t.TempDir(), "does-not-exist")
+	os.Setenv("PASSWORD_FILE", missingPath)
+
+	var opts Options
+	_, _, _, err := NewParser(&opts, Default|EnvironmentFallback).ParseCommandLine(nil)
+	if err == nil {
+		t.Fatal("expected an error for a missing env-file path")
+	}
+	if !strings.Contains(err.Error(), missingPath) {
+		t.Errorf("expected error to mention the file path %q, got: %v", missingPath, err)
+	}
+
+	var redacted Options
+	_, _, _, err = NewParser(&redacted, Default|EnvironmentFallback|SecretRedact).ParseCommandLine(nil)
+	if err == nil {
+		t.Fatal("expected an error for a missing env-file path")
+	}
+	if strings.Contains(err.Error(), missingPath) {
+		t.Errorf("SecretRedact should withhold the file path from the error, got: %v", err)
+	}
+}
+
+// TestSecretRedactDumpConfig checks that a value resolved via env-file
+// indirection is replaced with secretPlaceholder in WriteConfigFile and
+// WriteIni output when SecretRedact is set, and is written out in full
+// otherwise.
+func TestSecretRedactDumpConfig(t *testing.T) {
+	type Options struct {
+		Password string `long:"password" json:"password" env:"DB_PASSWORD"`
+	}
+
+	oldEnv := EnvSnapshot()
+	defer oldEnv.Restore()
+
+	path := filepath.Join(t.TempDir(), "db_password")
+	if err := os.WriteFile(path, []byte("super-secret-password\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("DB_PASSWORD_FILE", path)
+
+	var redacted Options
+	parser := NewParser(&redacted, Default|SecretRedact)
+	if _, _, _, err := parser.ParseCommandLine(nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var configOut strings.Builder
+	if err := parser.WriteConfigFile(&configOut, ConfigFormatJSON); err != nil {
+		t.Fatalf("WriteConfigFile: %v", err)
+	}
+	if strings.Contains(configOut.String(), "super-secret-password") {
+		t.Errorf("WriteConfigFile leaked the secret value with SecretRedact set:\n%s", configOut.String())
+	}
+	if !strings.Contains(configOut.String(), secretPlaceholder) {
+		t.Errorf("WriteConfigFile did not emit the secret placeholder:\n%s", configOut.String())
+	}
+
+	var iniOut strings.Builder
+	if err := parser.WriteIni(&iniOut); err != nil {
+		t.Fatalf("WriteIni: %v", err)
+	}
+	if strings.Contains(iniOut.String(), "super-secret-password") {
+		t.Errorf("WriteIni leaked the secret value with SecretRedact set:\n%s", iniOut.String())
+	}
+	if !strings.Contains(iniOut.String(), secretPlaceholder) {
+		t.Errorf("WriteIni did not emit the secret placeholder:\n%s", iniOut.String())
+	}
+
+	var unredacted Options
+	oldEnv.Restore()
+	os.Setenv("DB_PASSWORD_FILE", path)
+	plainParser := NewParser(&unredacted, Default)
+	if _, _, _, err := plainParser.ParseCommandLine(nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var plainOut strings.Builder
+	if err := plainParser.WriteConfigFile(&plainOut, ConfigFormatJSON); err != nil {
+		t.Fatalf("WriteConfigFile: %v", err)
+	}
+	if !strings.Contains(plainOut.String(), "super-secret-password") {
+		t.Errorf("WriteConfigFile should include the secret value without SecretRedact set:\n%s", plainOut.String())
+	}
+}
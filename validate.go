@@ -0,0 +1,349 @@
+package flags
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Validator validates a single option's resolved value against a
+// validator-specific parameter: the text following "=" in a
+// `validate:"name=param"` tag entry, or "" for parameterless validators
+// such as "nonempty". See Parser.RegisterValidator to add custom
+// validators beyond the builtin set.
+type Validator interface {
+	Validate(value reflect.Value, param string) error
+}
+
+// ValidatorFunc adapts a plain function to the Validator interface, in
+// the spirit of http.HandlerFunc.
+type ValidatorFunc func(value reflect.Value, param string) error
+
+// Validate calls fn.
+func (fn ValidatorFunc) Validate(value reflect.Value, param string) error {
+	return fn(value, param)
+}
+
+// builtinValidators holds the validators consulted by every Parser in
+// addition to any registered via Parser.RegisterValidator. "required_if"
+// is handled separately from this registry, since it needs access to a
+// sibling option's value rather than just the field being validated; see
+// Option.validateRequiredIf.
+var builtinValidators = map[string]Validator{
+	"min":         ValidatorFunc(validateMin),
+	"max":         ValidatorFunc(validateMax),
+	"range":       ValidatorFunc(validateRange),
+	"regexp":      ValidatorFunc(validateRegexp),
+	"oneof":       ValidatorFunc(validateOneof),
+	"cidr":        ValidatorFunc(validateCIDR),
+	"port":        ValidatorFunc(validatePort),
+	"url":         ValidatorFunc(validateURL),
+	"file_exists": ValidatorFunc(validateFileExists),
+	"nonempty":    ValidatorFunc(validateNonempty),
+}
+
+// RegisterValidator adds or overrides the validator consulted for
+// `validate:"name=param"` tag entries matching name, for parser-specific
+// rules beyond the builtin set (min, max, range, regexp, oneof,
+// required_if, cidr, port, url, file_exists and nonempty). Registering a
+// name already used by a builtin overrides it for this parser only.
+func (p *Parser) RegisterValidator(name string, fn func(reflect.Value, string) error) {
+	if p.validators == nil {
+		p.validators = make(map[string]Validator)
+	}
+	p.validators[name] = ValidatorFunc(fn)
+}
+
+// ValidationError aggregates every failure from a Parser.ParseCommandLine
+// validate:"..." pass into a single error, so callers that only check the
+// returned error still see that validation failed. Parser.ValidationErrors
+// holds the same failures individually, for callers that want to surface
+// every misconfigured option rather than just the first.
+type ValidationError struct {
+	Errors []error
+}
+
+// Error joins the individual validation failures into one message.
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// validateEntry is one comma-separated piece of a `validate:"..."` tag,
+// e.g. "min=1" parses to {name: "min", param: "1"}.
+type validateEntry struct {
+	name  string
+	param string
+}
+
+// parseValidateTag splits a `validate:"..."` tag into its entries.
+func parseValidateTag(tag string) []validateEntry {
+	if tag == "" {
+		return nil
+	}
+
+	var entries []validateEntry
+	for _, piece := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(piece, "=")
+		entries = append(entries, validateEntry{name: name, param: param})
+	}
+
+	return entries
+}
+
+// validate runs every option's `validate:"..."` tag entries, aggregating
+// every failure (rather than stopping at the first) so tools can surface
+// every misconfigured option in one run.
+func (p *Parser) validate() []error {
+	var errs []error
+
+	p.Group.walk(func(opt *Option) error {
+		for _, entry := range parseValidateTag(opt.Validate) {
+			var err error
+
+			if entry.name == "required_if" {
+				err = opt.validateRequiredIf(entry.param)
+			} else if validator := p.lookupValidator(entry.name); validator != nil {
+				err = validator.Validate(opt.value, entry.param)
+			} else {
+				err = fmt.Errorf("unknown validator %q", entry.name)
+			}
+
+			if err != nil {
+				errs = append(errs, fmt.Errorf("flags: option %s: %w", opt.Path(), err))
+			}
+		}
+		return nil
+	})
+
+	return errs
+}
+
+// lookupValidator returns the validator registered for name, preferring
+// one registered via RegisterValidator over the builtin of the same name.
+func (p *Parser) lookupValidator(name string) Validator {
+	if p.validators != nil {
+		if v, ok := p.validators[name]; ok {
+			return v
+		}
+	}
+
+	return builtinValidators[name]
+}
+
+// validateRequiredIf implements the "required_if" builtin: param is
+// "Field:Value". When the sibling option named Field currently stringifies
+// to Value, this option's own value must be non-zero.
+func (option *Option) validateRequiredIf(param string) error {
+	field, want, ok := strings.Cut(param, ":")
+	if !ok {
+		return fmt.Errorf("validate: invalid required_if parameter %q, expected Field:Value", param)
+	}
+
+	sibling := option.sibling(field)
+	if sibling == nil {
+		return fmt.Errorf("validate: required_if references unknown option %q", field)
+	}
+
+	if stringifyScalar(sibling.value) != want {
+		return nil
+	}
+
+	return validateNonempty(option.value, "")
+}
+
+// sibling returns the option in the same group as option whose `long`
+// tag (falling back to `json`) matches name.
+func (option *Option) sibling(name string) *Option {
+	if option.group == nil {
+		return nil
+	}
+
+	for _, candidate := range option.group.options {
+		if candidate.longOrJSON() == name {
+			return candidate
+		}
+	}
+
+	return nil
+}
+
+// numeric returns value as a float64 for the numeric validators, and
+// false if value is not a numeric kind.
+func numeric(value reflect.Value) (float64, bool) {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+func validateMin(value reflect.Value, param string) error {
+	n, ok := numeric(value)
+	if !ok {
+		return fmt.Errorf("validate: min requires a numeric field")
+	}
+
+	min, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return fmt.Errorf("validate: invalid min parameter %q: %w", param, err)
+	}
+
+	if n < min {
+		return fmt.Errorf("must be >= %s, got %v", param, n)
+	}
+
+	return nil
+}
+
+func validateMax(value reflect.Value, param string) error {
+	n, ok := numeric(value)
+	if !ok {
+		return fmt.Errorf("validate: max requires a numeric field")
+	}
+
+	max, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return fmt.Errorf("validate: invalid max parameter %q: %w", param, err)
+	}
+
+	if n > max {
+		return fmt.Errorf("must be <= %s, got %v", param, n)
+	}
+
+	return nil
+}
+
+// validateRange validates a "lo:hi" parameter, reusing validateMin and
+// validateMax for the two bounds.
+func validateRange(value reflect.Value, param string) error {
+	lo, hi, ok := strings.Cut(param, ":")
+	if !ok {
+		return fmt.Errorf("validate: invalid range parameter %q, expected lo:hi", param)
+	}
+
+	if err := validateMin(value, lo); err != nil {
+		return err
+	}
+
+	return validateMax(value, hi)
+}
+
+func validateRegexp(value reflect.Value, param string) error {
+	if value.Kind() != reflect.String {
+		return fmt.Errorf("validate: regexp requires a string field")
+	}
+
+	re, err := regexp.Compile(param)
+	if err != nil {
+		return fmt.Errorf("validate: invalid regexp parameter %q: %w", param, err)
+	}
+
+	if !re.MatchString(value.String()) {
+		return fmt.Errorf("must match %q, got %q", param, value.String())
+	}
+
+	return nil
+}
+
+// validateOneof checks value against a space-separated list of allowed
+// strings, e.g. `validate:"oneof=tcp udp icmp"`.
+func validateOneof(value reflect.Value, param string) error {
+	if value.Kind() != reflect.String {
+		return fmt.Errorf("validate: oneof requires a string field")
+	}
+
+	s := value.String()
+	for _, want := range strings.Fields(param) {
+		if s == want {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("must be one of [%s], got %q", param, s)
+}
+
+func validateCIDR(value reflect.Value, param string) error {
+	if value.Kind() != reflect.String {
+		return fmt.Errorf("validate: cidr requires a string field")
+	}
+
+	if _, _, err := net.ParseCIDR(value.String()); err != nil {
+		return fmt.Errorf("must be a valid CIDR, got %q", value.String())
+	}
+
+	return nil
+}
+
+func validatePort(value reflect.Value, param string) error {
+	n, ok := numeric(value)
+	if !ok {
+		return fmt.Errorf("validate: port requires a numeric field")
+	}
+
+	if n < 0 || n > 65535 {
+		return fmt.Errorf("must be a valid port (0-65535), got %v", n)
+	}
+
+	return nil
+}
+
+func validateURL(value reflect.Value, param string) error {
+	if value.Kind() != reflect.String {
+		return fmt.Errorf("validate: url requires a string field")
+	}
+
+	u, err := url.ParseRequestURI(value.String())
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("must be a valid absolute URL, got %q", value.String())
+	}
+
+	return nil
+}
+
+func validateFileExists(value reflect.Value, param string) error {
+	if value.Kind() != reflect.String {
+		return fmt.Errorf("validate: file_exists requires a string field")
+	}
+
+	if _, err := os.Stat(value.String()); err != nil {
+		return fmt.Errorf("file %q does not exist", value.String())
+	}
+
+	return nil
+}
+
+// validateNonempty fails on a string/slice/map of length zero, or any
+// other zero value; param is unused but kept to satisfy the Validator
+// signature.
+func validateNonempty(value reflect.Value, param string) error {
+	switch value.Kind() {
+	case reflect.String:
+		if value.String() == "" {
+			return fmt.Errorf("must not be empty")
+		}
+	case reflect.Slice, reflect.Map:
+		if value.Len() == 0 {
+			return fmt.Errorf("must not be empty")
+		}
+	default:
+		if value.IsZero() {
+			return fmt.Errorf("must not be empty")
+		}
+	}
+
+	return nil
+}
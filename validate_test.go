@@ -0,0 +1,100 @@
+package flags
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestValidateTags checks that builtin validators run after a successful
+// parse, and that a single ParseCommandLine call aggregates every failing
+// option into the returned ValidationError rather than stopping at the
+// first.
+func TestValidateTags(t *testing.T) {
+	type Options struct {
+		Port     int    `long:"port" validate:"port"`
+		Proto    string `long:"proto" validate:"oneof=tcp udp"`
+		Name     string `long:"name" validate:"nonempty"`
+		Token    string `long:"token"`
+		TokenReq string `long:"token-req" validate:"required_if=token:secret"`
+	}
+
+	var tests = []struct {
+		msg      string
+		args     []string
+		wantErrs int
+		errSub   []string
+	}{
+		{
+			msg:  "all valid",
+			args: []string{"--port=80", "--proto=tcp", "--name=x", "--token=secret", "--token-req=present"},
+		},
+		{
+			msg:      "port and proto both invalid, aggregated",
+			args:     []string{"--port=99999", "--proto=icmp", "--name=x"},
+			wantErrs: 2,
+			errSub:   []string{"port", "proto"},
+		},
+		{
+			msg:      "required_if triggers only when sibling matches",
+			args:     []string{"--port=1", "--proto=tcp", "--name=x", "--token=secret"},
+			wantErrs: 1,
+			errSub:   []string{"token-req"},
+		},
+	}
+
+	for _, test := range tests {
+		var opts Options
+		_, _, _, err := NewParser(&opts, Default).ParseCommandLine(test.args)
+
+		if test.wantErrs == 0 {
+			if err != nil {
+				t.Fatalf("%s:\nUnexpected error: %v", test.msg, err)
+			}
+			continue
+		}
+
+		if err == nil {
+			t.Fatalf("%s:\nExpected an error, got none", test.msg)
+		}
+
+		verr, ok := err.(*ValidationError)
+		if !ok {
+			t.Fatalf("%s:\nExpected *ValidationError, got %T: %v", test.msg, err, err)
+		}
+
+		if len(verr.Errors) != test.wantErrs {
+			t.Errorf("%s:\nExpected %d errors, got %d: %v", test.msg, test.wantErrs, len(verr.Errors), verr.Errors)
+		}
+
+		for _, sub := range test.errSub {
+			if !strings.Contains(err.Error(), sub) {
+				t.Errorf("%s:\nExpected error to mention %q, got %v", test.msg, sub, err)
+			}
+		}
+	}
+}
+
+// TestRegisterValidator checks that a parser-registered validator is
+// consulted in place of a builtin of the same name, and is scoped to the
+// parser it was registered on.
+func TestRegisterValidator(t *testing.T) {
+	type Options struct {
+		Code string `long:"code" validate:"oneof=red"`
+	}
+
+	var opts Options
+	p := NewParser(&opts, Default)
+	p.RegisterValidator("oneof", func(_ reflect.Value, param string) error {
+		return nil
+	})
+
+	if _, _, _, err := p.ParseCommandLine([]string{"--code=anything"}); err != nil {
+		t.Fatalf("Unexpected error with overridden validator: %v", err)
+	}
+
+	var other Options
+	if _, _, _, err := NewParser(&other, Default).ParseCommandLine([]string{"--code=anything"}); err == nil {
+		t.Error("expected the builtin oneof to apply on a parser without the override")
+	}
+}